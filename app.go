@@ -0,0 +1,54 @@
+package gotdd
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Views renders named templates with data, used by cmd/main handlers.
+type Views interface {
+	Render(w http.ResponseWriter, r *http.Request, name string, data interface{}) error
+}
+
+// App wires together the dependencies shared by the HTTP handlers and
+// middleware. cmd/main.MakeApp fills in Router and returns it.
+type App struct {
+	Router         chi.Router
+	Logger         *log.Logger
+	Session        *Session
+	Static         http.FileSystem
+	StaticPrefix   string
+	UserRepository UserRepository
+	AuthSources    map[string]AuthSource
+	RemoteUsers    RemoteUserRepository
+	RememberTokens RememberTokenRepository
+	RememberTTL    time.Duration
+	// CSRFHost, when set, is the only Origin/Referer host App.CSRF()
+	// accepts on unsafe requests.
+	CSRFHost         string
+	LoginThrottler   LoginThrottler
+	MinLoginDuration time.Duration
+	Views            Views
+	CORS             CORSConfig
+}
+
+// MinLoginDurationOrDefault returns a.MinLoginDuration, or
+// DefaultMinLoginDuration when it is unset.
+func (a App) MinLoginDurationOrDefault() time.Duration {
+	if a.MinLoginDuration > 0 {
+		return a.MinLoginDuration
+	}
+	return DefaultMinLoginDuration
+}
+
+// RememberTokenTTL returns a.RememberTTL, or DefaultRememberTokenTTL when
+// it is unset.
+func (a App) RememberTokenTTL() time.Duration {
+	if a.RememberTTL > 0 {
+		return a.RememberTTL
+	}
+	return DefaultRememberTokenTTL
+}
@@ -0,0 +1,43 @@
+package gotdd
+
+import "context"
+
+// ExternalIdentity is the subset of an external provider's profile that
+// gotdd cares about once an AuthSource has completed its exchange.
+type ExternalIdentity struct {
+	ExternalID string
+	Email      string
+}
+
+// AuthSource lets an operator plug in an OAuth2/OIDC provider (GitHub,
+// GitLab, a generic OIDC issuer, ...). ID is used both as the map key in
+// App.AuthSources and as the {provider} path segment of the /auth routes.
+type AuthSource interface {
+	ID() string
+	AuthorizeURL(state string) string
+	Authenticate(ctx context.Context, code string) (ExternalIdentity, error)
+}
+
+// RemoteUser is the placeholder account created the first time someone
+// authenticates through an AuthSource. It is keyed by (SourceID,
+// ExternalID); once promoted, UserSID points at the full local User that
+// future logins (local or remote) resolve to.
+type RemoteUser struct {
+	SID        string
+	SourceID   string
+	ExternalID string
+	Email      string
+	UserSID    string
+}
+
+// RemoteUserRepository persists RemoteUser placeholders and links
+// ("promotes") them to local accounts.
+type RemoteUserRepository interface {
+	// FindOrCreate returns the RemoteUser for (sourceID, externalID),
+	// creating a new placeholder on first sight.
+	FindOrCreate(sourceID, externalID, email string) (RemoteUser, error)
+	// Promote links the remote user identified by remoteSID to a full
+	// local account and returns that account's SID. Calling it again for
+	// an already-promoted remote user is a no-op that returns the same SID.
+	Promote(remoteSID string) (string, error)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/go-chi/chi/v5"
+)
+
+const oauthStateCookie = "oauth_state"
+
+// authLogin sends the visitor off to the provider's consent screen,
+// stashing a random state value to be checked on the way back.
+func authLogin(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source, ok := app.AuthSources[chi.URLParam(r, "provider")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		state := randomState()
+		http.SetCookie(w, &http.Cookie{
+			Name:     oauthStateCookie,
+			Value:    state,
+			Path:     "/",
+			HttpOnly: true,
+		})
+
+		http.Redirect(w, r, source.AuthorizeURL(state), http.StatusFound)
+	}
+}
+
+// authCallback exchanges the authorization code for an ExternalIdentity,
+// finds or creates the matching RemoteUser, promotes it to a full local
+// user on first use, and logs the visitor in exactly like a password login.
+func authCallback(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := chi.URLParam(r, "provider")
+		source, ok := app.AuthSources[provider]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		stateCookie, err := r.Cookie(oauthStateCookie)
+		if err != nil || stateCookie.Value == "" || stateCookie.Value != r.URL.Query().Get("state") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		identity, err := source.Authenticate(r.Context(), r.URL.Query().Get("code"))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		remote, err := app.RemoteUsers.FindOrCreate(provider, identity.ExternalID, identity.Email)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		userSID := remote.UserSID
+		if userSID == "" {
+			if userSID, err = app.RemoteUsers.Promote(remote.SID); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := app.Session.Login(w, r, userSID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+func randomState() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
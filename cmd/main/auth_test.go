@@ -0,0 +1,144 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/alcalbg/gotdd/cmd/main"
+	"github.com/alcalbg/gotdd/cmd/main/views"
+	"github.com/alcalbg/gotdd/test/assert"
+	"github.com/alcalbg/gotdd/test/doubles"
+	"github.com/gorilla/sessions"
+)
+
+// fakeAuthSource stands in for a real OAuth2/OIDC provider: Authenticate
+// "exchanges" a code by asking a httptest server for the identity it was
+// told to hand back.
+type fakeAuthSource struct {
+	id     string
+	server *httptest.Server
+}
+
+func (s fakeAuthSource) ID() string { return s.id }
+
+func (s fakeAuthSource) AuthorizeURL(state string) string {
+	return s.server.URL + "/authorize?state=" + state
+}
+
+func (s fakeAuthSource) Authenticate(ctx context.Context, code string) (gotdd.ExternalIdentity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.server.URL+"/token?code="+code, nil)
+	if err != nil {
+		return gotdd.ExternalIdentity{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return gotdd.ExternalIdentity{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gotdd.ExternalIdentity{}, gotdd.ErrUserNotFound
+	}
+
+	var identity gotdd.ExternalIdentity
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return gotdd.ExternalIdentity{}, err
+	}
+	return identity, nil
+}
+
+func fakeOAuthServer(t *testing.T, validCode string, identity gotdd.ExternalIdentity) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("code") != validCode {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(identity)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOAuthLogin(t *testing.T) {
+
+	identity := gotdd.ExternalIdentity{ExternalID: "gh-42", Email: "octocat@example.com"}
+	server := fakeOAuthServer(t, "validcode", identity)
+	source := fakeAuthSource{id: "github", server: server}
+
+	newApp := func() gotdd.App {
+		return main.MakeApp(gotdd.App{
+			Session:     gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
+			Views:       views.EmbededViews,
+			AuthSources: map[string]gotdd.AuthSource{"github": source},
+			RemoteUsers: doubles.NewRemoteUserRepositoryStub(),
+		})
+	}
+
+	t.Run("redirects to the provider's authorize URL", func(t *testing.T) {
+		app := newApp()
+		request := httptest.NewRequest(http.MethodGet, "/auth/github/login", nil)
+		response := httptest.NewRecorder()
+
+		app.Router.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusFound, response.Code)
+		assert.Contains(t, response.Result().Header.Get("Location"), server.URL+"/authorize")
+	})
+
+	t.Run("unknown provider is not found", func(t *testing.T) {
+		app := newApp()
+		request := httptest.NewRequest(http.MethodGet, "/auth/bogus/login", nil)
+		response := httptest.NewRecorder()
+
+		app.Router.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("callback with a bad code is unauthorized", func(t *testing.T) {
+		app := newApp()
+
+		loginResp := httptest.NewRecorder()
+		app.Router.ServeHTTP(loginResp, httptest.NewRequest(http.MethodGet, "/auth/github/login", nil))
+		state := loginResp.Result().Cookies()[0]
+
+		request := httptest.NewRequest(http.MethodGet, "/auth/github/callback?code=wrong&state="+state.Value, nil)
+		request.AddCookie(state)
+		response := httptest.NewRecorder()
+
+		app.Router.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("first callback creates a remote user, promotes it and logs them in", func(t *testing.T) {
+		app := newApp()
+
+		// step1: visit /auth/github/login to obtain the state cookie
+		loginResp := httptest.NewRecorder()
+		app.Router.ServeHTTP(loginResp, httptest.NewRequest(http.MethodGet, "/auth/github/login", nil))
+		state := loginResp.Result().Cookies()[0]
+
+		// step2: provider redirects back with a valid code
+		request := httptest.NewRequest(http.MethodGet, "/auth/github/callback?code=validcode&state="+state.Value, nil)
+		request.AddCookie(state)
+		response := httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		assert.Redirects(t, response, "/", http.StatusFound)
+		sessionCookies := response.Result().Cookies()
+
+		// step3: the session now reflects a logged in (promoted) user
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, c := range sessionCookies {
+			request.AddCookie(c)
+		}
+		response = httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
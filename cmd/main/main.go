@@ -0,0 +1,169 @@
+// Package main assembles gotdd.App into a runnable HTTP server: it owns
+// routing and the handlers, and leaves all other dependencies (session
+// store, user repository, views, ...) to be injected by the caller.
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MakeApp wires up app.Router and returns app.
+func MakeApp(app gotdd.App) gotdd.App {
+	app.CORS.AllowRoute("/", []string{http.MethodGet}, nil)
+	app.CORS.AllowRoute("/profile", []string{http.MethodGet}, nil)
+	app.CORS.AllowRoute("/login", []string{http.MethodGet, http.MethodPost}, []string{gotdd.CSRFHeaderName})
+	app.CORS.AllowRoute("/logout", []string{http.MethodPost}, []string{gotdd.CSRFHeaderName})
+
+	r := chi.NewRouter()
+
+	r.Use(app.Logging())
+	r.Use(app.Recoverer("/error"))
+	r.Use(app.Cors())
+
+	if app.Static != nil {
+		fileServer(r, app.StaticPrefix, app.Static)
+	}
+
+	// CSRF and Flash only apply to the session-aware, form-rendering
+	// routes: leaving them out of the static/OAuth routes means a
+	// mismatched method on an unrelated path still falls through to
+	// chi's own 404/405 handling instead of a CSRF 403.
+	//
+	// Flash wraps CSRF, not the other way round, so that Flash's single
+	// deferred session save (see App.Flash()) also covers the save CSRF
+	// does the first time it issues a token - otherwise that save would
+	// happen too early and race a later one carrying more session state
+	// (e.g. a remember-me auto-login) into a second, shadowed Set-Cookie.
+	r.Group(func(r chi.Router) {
+		r.Use(app.Flash())
+		r.Use(app.CSRF())
+
+		r.With(app.Authenticate("/login")).Get("/", home(app))
+		r.With(app.Authenticate("/login")).Get("/profile", profile(app))
+
+		r.With(app.RedirectIfAuthenticated("/")).Get("/login", showLogin(app))
+		r.Post("/login", login(app))
+		r.Post("/logout", logout(app))
+	})
+
+	r.Get("/auth/{provider}/login", authLogin(app))
+	r.Get("/auth/{provider}/callback", authCallback(app))
+
+	app.Router = r
+	return app
+}
+
+func fileServer(r chi.Router, prefix string, root http.FileSystem) {
+	if prefix == "" {
+		prefix = "/"
+	}
+	handler := http.StripPrefix(prefix, http.FileServer(root))
+	path := prefix
+	if path != "/" {
+		path += "/*"
+	} else {
+		path = "/*"
+	}
+	r.Get(path, handler.ServeHTTP)
+}
+
+func home(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.Views.Render(w, r, "home", nil)
+	}
+}
+
+func profile(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.Views.Render(w, r, "profile", nil)
+	}
+}
+
+func showLogin(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.Views.Render(w, r, "login", nil)
+	}
+}
+
+func login(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		email := r.FormValue("email")
+		password := r.FormValue("password")
+		ip := clientIP(r)
+
+		if app.LoginThrottler != nil {
+			if ok, retryAfter := app.LoginThrottler.Allow(email, ip); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		user, err := gotdd.User{}, gotdd.ErrUserNotFound
+		if app.UserRepository != nil {
+			user, err = app.UserRepository.GetUserByEmail(email)
+		}
+		if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			if app.LoginThrottler != nil {
+				app.LoginThrottler.RecordFailure(email, ip)
+			}
+			gotdd.PadLoginDuration(start, app.MinLoginDurationOrDefault())
+			app.Session.AddFlash(w, r, gotdd.FlashError, "Invalid email or password.")
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+
+		if app.LoginThrottler != nil {
+			app.LoginThrottler.RecordSuccess(email, ip)
+		}
+
+		if err := app.Session.Login(w, r, user.SID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.FormValue("remember") != "" && app.RememberTokens != nil {
+			gotdd.IssueRememberToken(w, app.RememberTokens, user.SID, app.RememberTokenTTL())
+		}
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+// clientIP returns the request's remote address without its port, for use
+// as half of the LoginThrottler key.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func logout(app gotdd.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userSID := app.Session.UserSID(r)
+		app.Session.Logout(w, r)
+
+		if app.RememberTokens != nil {
+			app.RememberTokens.DeleteAllForUser(userSID)
+			http.SetCookie(w, &http.Cookie{
+				Name:   gotdd.RememberCookieName,
+				Value:  "",
+				Path:   "/",
+				MaxAge: -1,
+			})
+		}
+
+		app.Session.AddFlash(w, r, gotdd.FlashSuccess, "You have been logged out.")
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -16,6 +17,27 @@ import (
 	"github.com/gorilla/sessions"
 )
 
+var csrfFieldPattern = regexp.MustCompile(`name="csrf_token" value="([^"]*)"`)
+
+// extractCSRFToken pulls the value out of the hidden csrf_field input
+// rendered into the page's form.
+func extractCSRFToken(body string) string {
+	m := csrfFieldPattern.FindStringSubmatch(body)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+// csrfToken performs a GET against path to obtain a fresh CSRF token and
+// the session cookies that go with it.
+func csrfToken(app gotdd.App, path string) (token string, cookies []*http.Cookie) {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	response := httptest.NewRecorder()
+	app.Router.ServeHTTP(response, request)
+	return extractCSRFToken(response.Body.String()), response.Result().Cookies()
+}
+
 func TestRoutes(t *testing.T) {
 	routes := []struct {
 		userSID string
@@ -121,16 +143,44 @@ func TestLogin(t *testing.T) {
 		data.Set("email", "nonexisting@example.com")
 		data.Set("password", "bad")
 
-		request := loginRequest(data)
-		response := httptest.NewRecorder()
 		app := main.MakeApp(gotdd.App{
 			Session:        gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
 			UserRepository: doubles.NewUserRepositoryStub(),
 			Views:          views.EmbededViews,
 		})
+
+		// step0: GET the login form to obtain a CSRF token
+		token, cookies := csrfToken(app, "/login")
+		data.Set("csrf_token", token)
+
+		// step1: failed login redirects back to the login page (PRG)
+		request := loginRequest(data, cookies)
+		response := httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		assert.Redirects(t, response, "/login", http.StatusFound)
+		gotCookies := response.Result().Cookies()
+
+		// step2: the login page shows the flash error exactly once
+		request = httptest.NewRequest(http.MethodGet, "/login", nil)
+		for _, c := range gotCookies {
+			request.AddCookie(c)
+		}
+		response = httptest.NewRecorder()
 		app.Router.ServeHTTP(response, request)
-		assert.Equal(t, http.StatusUnauthorized, response.Code)
 		assert.Contains(t, response.Body.String(), htmlNeedle)
+		assert.Contains(t, response.Body.String(), "Invalid email or password")
+		gotCookies = response.Result().Cookies()
+
+		// step3: and it's gone on the next request
+		request = httptest.NewRequest(http.MethodGet, "/login", nil)
+		for _, c := range gotCookies {
+			request.AddCookie(c)
+		}
+		response = httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		if strings.Contains(response.Body.String(), "Invalid email or password") {
+			t.Error("flash message should not be shown twice")
+		}
 	})
 
 	t.Run("submit login with bad password", func(t *testing.T) {
@@ -138,16 +188,56 @@ func TestLogin(t *testing.T) {
 		data.Set("email", doubles.UserStub().Email)
 		data.Set("password", "bad")
 
-		request := loginRequest(data)
+		app := main.MakeApp(gotdd.App{
+			Session:        gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
+			UserRepository: doubles.NewUserRepositoryStub(),
+			Views:          views.EmbededViews,
+		})
+
+		token, cookies := csrfToken(app, "/login")
+		data.Set("csrf_token", token)
+
+		request := loginRequest(data, cookies)
 		response := httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		assert.Redirects(t, response, "/login", http.StatusFound)
+	})
+
+	t.Run("submit login without a csrf token is forbidden", func(t *testing.T) {
+		data := url.Values{}
+		data.Set("email", doubles.UserStub().Email)
+		data.Set("password", "pass123")
+
 		app := main.MakeApp(gotdd.App{
 			Session:        gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
 			UserRepository: doubles.NewUserRepositoryStub(),
 			Views:          views.EmbededViews,
 		})
+
+		request := loginRequest(data, nil)
+		response := httptest.NewRecorder()
 		app.Router.ServeHTTP(response, request)
-		assert.Equal(t, http.StatusUnauthorized, response.Code)
-		assert.Contains(t, response.Body.String(), htmlNeedle)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("submit login with a forged csrf token is forbidden", func(t *testing.T) {
+		data := url.Values{}
+		data.Set("email", doubles.UserStub().Email)
+		data.Set("password", "pass123")
+
+		app := main.MakeApp(gotdd.App{
+			Session:        gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
+			UserRepository: doubles.NewUserRepositoryStub(),
+			Views:          views.EmbededViews,
+		})
+
+		_, cookies := csrfToken(app, "/login")
+		data.Set("csrf_token", "forged-token")
+
+		request := loginRequest(data, cookies)
+		response := httptest.NewRecorder()
+		app.Router.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusForbidden, response.Code)
 	})
 
 	t.Run("submit successful login and go to the home page", func(t *testing.T) {
@@ -161,8 +251,11 @@ func TestLogin(t *testing.T) {
 			Views:          views.EmbededViews,
 		})
 
+		token, cookies := csrfToken(app, "/login")
+		data.Set("csrf_token", token)
+
 		// step1: after successful login, user is redirected to the home page
-		request := loginRequest(data)
+		request := loginRequest(data, cookies)
 		response := httptest.NewRecorder()
 		app.Router.ServeHTTP(response, request)
 		assert.Redirects(t, response, "/", http.StatusFound)
@@ -188,15 +281,19 @@ func TestLogout(t *testing.T) {
 		Views:   views.EmbededViews,
 	})
 
-	// first, let's make sure we're logged in
-	request := httptest.NewRequest(http.MethodGet, "/", nil)
-	response := httptest.NewRecorder()
-	app.Router.ServeHTTP(response, request)
-	assert.Equal(t, http.StatusOK, response.Code)
+	// first, let's make sure we're logged in, and grab the CSRF token
+	// rendered into the home page's logout form
+	token, cookies := csrfToken(app, "/")
 
 	// try to log out
-	request = httptest.NewRequest(http.MethodPost, "/logout", nil)
-	response = httptest.NewRecorder()
+	data := url.Values{}
+	data.Set("csrf_token", token)
+	request := httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(data.Encode()))
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
+	response := httptest.NewRecorder()
 	app.Router.ServeHTTP(response, request)
 	assert.Redirects(t, response, "/login", http.StatusFound)
 
@@ -207,8 +304,11 @@ func TestLogout(t *testing.T) {
 	assert.Redirects(t, response, "/login", http.StatusFound)
 }
 
-func loginRequest(form url.Values) *http.Request {
+func loginRequest(form url.Values, cookies []*http.Cookie) *http.Request {
 	request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
 	return request
 }
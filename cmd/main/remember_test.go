@@ -0,0 +1,58 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/alcalbg/gotdd/cmd/main"
+	"github.com/alcalbg/gotdd/cmd/main/views"
+	"github.com/alcalbg/gotdd/test/assert"
+	"github.com/alcalbg/gotdd/test/doubles"
+	"github.com/gorilla/sessions"
+)
+
+func TestRememberMeLogin(t *testing.T) {
+
+	rememberTokens := doubles.NewRememberTokenRepositoryStub()
+
+	app := main.MakeApp(gotdd.App{
+		Session:        gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
+		UserRepository: doubles.NewUserRepositoryStub(),
+		RememberTokens: rememberTokens,
+		Views:          views.EmbededViews,
+	})
+
+	token, cookies := csrfToken(app, "/login")
+
+	data := url.Values{}
+	data.Set("email", doubles.UserStub().Email)
+	data.Set("password", "pass123")
+	data.Set("remember", "1")
+	data.Set("csrf_token", token)
+
+	request := loginRequest(data, cookies)
+	response := httptest.NewRecorder()
+	app.Router.ServeHTTP(response, request)
+	assert.Redirects(t, response, "/", http.StatusFound)
+
+	var rememberCookie *http.Cookie
+	for _, c := range response.Result().Cookies() {
+		if c.Name == gotdd.RememberCookieName {
+			rememberCookie = c
+		}
+	}
+	if rememberCookie == nil {
+		t.Fatal("expected a remember-me cookie to be set")
+	}
+
+	// a brand new session (no session cookie at all) should still be
+	// logged in purely from the remember-me cookie
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(rememberCookie)
+	response = httptest.NewRecorder()
+	app.Router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
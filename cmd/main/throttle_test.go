@@ -0,0 +1,75 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/alcalbg/gotdd/cmd/main"
+	"github.com/alcalbg/gotdd/cmd/main/views"
+	"github.com/alcalbg/gotdd/test/assert"
+	"github.com/alcalbg/gotdd/test/doubles"
+	"github.com/gorilla/sessions"
+)
+
+func newThrottledApp() gotdd.App {
+	return main.MakeApp(gotdd.App{
+		Session:          gotdd.NewSession(sessions.NewCookieStore([]byte("abc"))),
+		UserRepository:   doubles.NewUserRepositoryStub(),
+		Views:            views.EmbededViews,
+		LoginThrottler:   gotdd.NewInMemoryLoginThrottler(2, time.Minute),
+		MinLoginDuration: 10 * time.Millisecond,
+	})
+}
+
+func attemptLogin(app gotdd.App, password string) *httptest.ResponseRecorder {
+	token, cookies := csrfToken(app, "/login")
+
+	data := url.Values{}
+	data.Set("email", doubles.UserStub().Email)
+	data.Set("password", password)
+	data.Set("csrf_token", token)
+
+	response := httptest.NewRecorder()
+	app.Router.ServeHTTP(response, loginRequest(data, cookies))
+	return response
+}
+
+func TestLoginThrottling(t *testing.T) {
+
+	t.Run("locks out after repeated failures and reports Retry-After", func(t *testing.T) {
+		app := newThrottledApp()
+
+		attemptLogin(app, "bad")
+		attemptLogin(app, "bad")
+		response := attemptLogin(app, "bad")
+
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		if response.Header().Get("Retry-After") == "" {
+			t.Error("expected a Retry-After header")
+		}
+	})
+
+	t.Run("failed and successful logins take comparable wall-clock time", func(t *testing.T) {
+		app := newThrottledApp()
+
+		failStart := time.Now()
+		attemptLogin(app, "bad")
+		failedElapsed := time.Since(failStart)
+
+		successStart := time.Now()
+		attemptLogin(app, "pass123")
+		successElapsed := time.Since(successStart)
+
+		diff := failedElapsed - successElapsed
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > 200*time.Millisecond {
+			t.Errorf("expected comparable timing for success/failure, got failed=%v success=%v", failedElapsed, successElapsed)
+		}
+	})
+}
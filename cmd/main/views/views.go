@@ -0,0 +1,45 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/alcalbg/gotdd"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+// embededViews renders templates compiled into the binary.
+type embededViews struct{}
+
+// EmbededViews is the default Views implementation backed by the
+// templates embedded in this package.
+var EmbededViews = embededViews{}
+
+// viewData is what every template actually executes against: the
+// handler's own data alongside whatever App.Flash() queued for this
+// request.
+type viewData struct {
+	Data    interface{}
+	Flashes []gotdd.Flash
+}
+
+func (v embededViews) Render(w http.ResponseWriter, r *http.Request, name string, data interface{}) error {
+	csrfToken, _ := r.Context().Value(gotdd.CSRFContextKey).(string)
+	funcs := template.FuncMap{
+		"csrf_field": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + gotdd.CSRFFieldName + `" value="` + csrfToken + `">`)
+		},
+	}
+
+	tmpl, err := template.New(name + ".html").Funcs(funcs).ParseFS(templatesFS, "templates/"+name+".html")
+	if err != nil {
+		return err
+	}
+
+	flashes, _ := r.Context().Value(gotdd.FlashesContextKey).([]gotdd.Flash)
+
+	return tmpl.ExecuteTemplate(w, name+".html", viewData{Data: data, Flashes: flashes})
+}
@@ -0,0 +1,120 @@
+package gotdd
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig controls App.Cors(). The zero value allows no origins, so
+// cross-origin requests are rejected until AllowedOrigins is populated.
+type CORSConfig struct {
+	// AllowedOrigins may be exact origins ("https://example.com") or carry
+	// a single "*" wildcard, e.g. "https://*.example.com" to match any
+	// subdomain.
+	AllowedOrigins   []string
+	AllowCredentials bool
+	ExposedHeaders   []string
+	// MaxAge, when set, is sent as Access-Control-Max-Age on preflight
+	// responses so browsers can cache them.
+	MaxAge time.Duration
+
+	routeMethods map[string][]string
+	routeHeaders map[string][]string
+}
+
+// AllowRoute registers the methods (and, optionally, the request headers)
+// CORS permits for path. A preflight request for path asking for a method
+// that isn't in methods is rejected with 405. Routes with no registration
+// are preflighted without a method check.
+func (c *CORSConfig) AllowRoute(path string, methods []string, headers []string) {
+	if c.routeMethods == nil {
+		c.routeMethods = map[string][]string{}
+	}
+	if c.routeHeaders == nil {
+		c.routeHeaders = map[string][]string{}
+	}
+	c.routeMethods[path] = methods
+	c.routeHeaders[path] = headers
+}
+
+func (c CORSConfig) originAllowed(origin string) bool {
+	for _, pattern := range c.AllowedOrigins {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin reports whether origin satisfies pattern, where pattern may
+// contain a single "*" standing in for any run of characters.
+func matchOrigin(pattern, origin string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star == -1 {
+		return pattern == origin
+	}
+	prefix, suffix := pattern[:star], pattern[star+1:]
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) &&
+		strings.HasSuffix(origin, suffix)
+}
+
+func allowsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cors allows cross-origin requests from a.CORS.AllowedOrigins, echoing
+// back the request's Origin (rather than always "*") so that credentialed
+// requests can be allowed. It short-circuits OPTIONS preflights with 204,
+// rejecting them with 405 when app.CORS.AllowRoute restricts the path to
+// a different set of methods.
+func (a App) Cors() func(http.Handler) http.Handler {
+	cfg := a.CORS
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			allowed := origin != "" && cfg.originAllowed(origin)
+
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if len(cfg.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+			}
+
+			if r.Method != http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowed {
+				if methods, ok := cfg.routeMethods[r.URL.Path]; ok {
+					if requested := r.Header.Get("Access-Control-Request-Method"); requested != "" && !allowsMethod(methods, requested) {
+						w.WriteHeader(http.StatusMethodNotAllowed)
+						return
+					}
+					w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				}
+				if headers := cfg.routeHeaders[r.URL.Path]; len(headers) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+				}
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package gotdd
+
+import "net/http"
+
+// CSRFFieldName and CSRFHeaderName are where App.CSRF() looks for the
+// token on unsafe requests: the header first (for fetch/XHR callers),
+// falling back to the form field (for plain HTML forms).
+const (
+	CSRFFieldName  = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+const csrfSessionKey = "csrf_token"
+
+type csrfContextKey struct{}
+
+// CSRFContextKey is where App.CSRF() stores the current request's token,
+// for views.EmbededViews (or any other Views implementation) to render
+// via a csrf_field template helper.
+var CSRFContextKey = csrfContextKey{}
+
+// CSRFToken returns the per-session CSRF token, generating and persisting
+// one the first time it's requested.
+func (s *Session) CSRFToken(w http.ResponseWriter, r *http.Request) string {
+	session := s.getSession(r)
+
+	if token, ok := session.Values[csrfSessionKey].(string); ok && token != "" {
+		return token
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return ""
+	}
+	session.Values[csrfSessionKey] = token
+	s.save(w, r)
+	return token
+}
@@ -0,0 +1,126 @@
+package gotdd_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/alcalbg/gotdd/test/assert"
+	"github.com/gorilla/sessions"
+)
+
+func TestCSRFSafeMethodsPassThroughAndExposeAToken(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	response := httptest.NewRecorder()
+
+	var token string
+	app := gotdd.App{Session: gotdd.NewSession(sessions.NewCookieStore([]byte("abc")))}
+	handler := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ = r.Context().Value(gotdd.CSRFContextKey).(string)
+	}))
+
+	handler.ServeHTTP(response, request)
+
+	if token == "" {
+		t.Fatal("expected a csrf token in the request context")
+	}
+}
+
+func TestCSRFRejectsMissingToken(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	response := httptest.NewRecorder()
+
+	app := gotdd.App{Session: gotdd.NewSession(sessions.NewCookieStore([]byte("abc")))}
+	handler := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be reached")
+	}))
+
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestCSRFRejectsForgedToken(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("abc"))
+	app := gotdd.App{Session: gotdd.NewSession(store)}
+
+	// priming GET: safe methods pass through, so this needs a handler that
+	// tolerates being reached, unlike the POST below.
+	primer := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	getResp := httptest.NewRecorder()
+	primer.ServeHTTP(getResp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	data := url.Values{}
+	data.Set(gotdd.CSRFFieldName, "forged-token")
+	postReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.Encode()))
+	postReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range getResp.Result().Cookies() {
+		postReq.AddCookie(c)
+	}
+	postResp := httptest.NewRecorder()
+
+	handler := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be reached")
+	}))
+	handler.ServeHTTP(postResp, postReq)
+
+	assert.Equal(t, http.StatusForbidden, postResp.Code)
+}
+
+func TestCSRFAcceptsMatchingToken(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("abc"))
+	app := gotdd.App{Session: gotdd.NewSession(store)}
+
+	var token string
+	handler := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ = r.Context().Value(gotdd.CSRFContextKey).(string)
+	}))
+
+	getResp := httptest.NewRecorder()
+	handler.ServeHTTP(getResp, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := getResp.Result().Cookies()
+
+	data := url.Values{}
+	data.Set(gotdd.CSRFFieldName, token)
+	postReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.Encode()))
+	postReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		postReq.AddCookie(c)
+	}
+	postResp := httptest.NewRecorder()
+
+	handler.ServeHTTP(postResp, postReq)
+
+	assert.Equal(t, http.StatusOK, postResp.Code)
+}
+
+func TestCSRFRejectsMismatchedOrigin(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("abc"))
+	app := gotdd.App{Session: gotdd.NewSession(store), CSRFHost: "example.com"}
+
+	var token string
+	handler := app.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ = r.Context().Value(gotdd.CSRFContextKey).(string)
+	}))
+
+	getResp := httptest.NewRecorder()
+	handler.ServeHTTP(getResp, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := getResp.Result().Cookies()
+
+	data := url.Values{}
+	data.Set(gotdd.CSRFFieldName, token)
+	postReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.Encode()))
+	postReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	postReq.Header.Set("Origin", "https://evil.com")
+	for _, c := range cookies {
+		postReq.AddCookie(c)
+	}
+	postResp := httptest.NewRecorder()
+
+	handler.ServeHTTP(postResp, postReq)
+
+	assert.Equal(t, http.StatusForbidden, postResp.Code)
+}
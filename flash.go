@@ -0,0 +1,58 @@
+package gotdd
+
+import (
+	"encoding/gob"
+	"net/http"
+)
+
+// FlashLevel categorizes a Flash so templates can style it appropriately.
+type FlashLevel string
+
+const (
+	FlashInfo    FlashLevel = "info"
+	FlashSuccess FlashLevel = "success"
+	FlashWarning FlashLevel = "warning"
+	FlashError   FlashLevel = "error"
+)
+
+// Flash is a one-time message queued on a Session and read (and cleared)
+// on the very next request, typically after a redirect (PRG).
+type Flash struct {
+	Level   FlashLevel
+	Message string
+}
+
+func init() {
+	gob.Register(Flash{})
+}
+
+type flashesContextKey struct{}
+
+// FlashesContextKey is where App.Flash() stores the current request's
+// flash messages in its context, for views.EmbededViews (or any other
+// Views implementation) to pick up and hand to templates.
+var FlashesContextKey = flashesContextKey{}
+
+// AddFlash queues a flash message for the next request.
+func (s *Session) AddFlash(w http.ResponseWriter, r *http.Request, level FlashLevel, message string) error {
+	session := s.getSession(r)
+	session.AddFlash(Flash{Level: level, Message: message})
+	return s.save(w, r)
+}
+
+// Flashes returns the flash messages queued on a previous request,
+// clearing them so they aren't shown again. Callers are responsible for
+// persisting the session afterwards - App.Flash() does this once per
+// request, after the handler runs.
+func (s *Session) Flashes(r *http.Request) []Flash {
+	session := s.getSession(r)
+	raw := session.Flashes()
+
+	flashes := make([]Flash, 0, len(raw))
+	for _, v := range raw {
+		if f, ok := v.(Flash); ok {
+			flashes = append(flashes, f)
+		}
+	}
+	return flashes
+}
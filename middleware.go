@@ -0,0 +1,212 @@
+package gotdd
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Logging writes a single line per request to a.Logger (or os.Stderr).
+func (a App) Logging() func(http.Handler) http.Handler {
+	logger := a.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, r)
+			logger.Printf("%s %s (%s)", r.Method, r.URL.Path, time.Since(start))
+		})
+	}
+}
+
+// Recoverer recovers from panics, logs them and redirects to destination.
+func (a App) Recoverer(destination string) func(http.Handler) http.Handler {
+	logger := a.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					logger.Printf("panic: %v", err)
+					http.Redirect(w, r, destination, http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Flash makes the flash messages queued on the previous request available
+// to handlers and views via FlashesContextKey, clearing them in the
+// process so they're only ever shown once. It saves the session exactly
+// once per request, right before the first byte or status code is
+// written, so that a handler which also mutates the session (e.g.
+// AddFlash, Login) doesn't produce a second, conflicting Set-Cookie
+// header alongside the one for the cleared flash. Saving any later than
+// that is too late: login and logout both end in http.Redirect, which
+// calls WriteHeader, and net/http silently drops headers set afterwards.
+func (a App) Flash() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.Session == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			flashes := a.Session.Flashes(r)
+			r = r.WithContext(context.WithValue(r.Context(), FlashesContextKey, flashes))
+			r = r.WithContext(context.WithValue(r.Context(), deferredSaveContextKey{}, true))
+
+			sw := &sessionSaveWriter{ResponseWriter: w, session: a.Session, request: r}
+			next.ServeHTTP(sw, r)
+			sw.flush()
+		})
+	}
+}
+
+// sessionSaveWriter saves its session exactly once: on the first write or
+// WriteHeader call, or - if the handler never writes anything - once
+// ServeHTTP returns.
+type sessionSaveWriter struct {
+	http.ResponseWriter
+	session *Session
+	request *http.Request
+	saved   bool
+}
+
+func (w *sessionSaveWriter) flush() {
+	if w.saved {
+		return
+	}
+	w.saved = true
+	w.session.getSession(w.request).Save(w.request, w.ResponseWriter)
+}
+
+func (w *sessionSaveWriter) WriteHeader(status int) {
+	w.flush()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sessionSaveWriter) Write(b []byte) (int, error) {
+	w.flush()
+	return w.ResponseWriter.Write(b)
+}
+
+// CSRF generates a per-session token (available to handlers and views via
+// CSRFContextKey) and, for unsafe methods, requires that token back
+// either as the CSRFFieldName form field or the CSRFHeaderName header,
+// comparing in constant time. It also rejects unsafe requests whose
+// Origin or Referer doesn't match CSRFHost, when that's configured.
+func (a App) CSRF() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.Session == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := a.Session.CSRFToken(w, r)
+			r = r.WithContext(context.WithValue(r.Context(), CSRFContextKey, token))
+
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !originMatches(r, a.CSRFHost) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			got := r.Header.Get(CSRFHeaderName)
+			if got == "" {
+				got = r.FormValue(CSRFFieldName)
+			}
+
+			if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// originMatches reports whether r's Origin and Referer (when present)
+// both point at host. An empty host disables the check.
+func originMatches(r *http.Request, host string) bool {
+	if host == "" {
+		return true
+	}
+	for _, raw := range []string{r.Header.Get("Origin"), r.Header.Get("Referer")} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host != host {
+			return false
+		}
+	}
+	return true
+}
+
+// Authenticate redirects guests to destination instead of serving the
+// page. A guest carrying a valid "remember me" cookie is transparently
+// logged back in first, so the rest of the stack sees a normal session.
+func (a App) Authenticate(destination string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.Session.IsAuthenticated(r) {
+				if a.rememberLogin(w, r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				http.Redirect(w, r, destination, http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rememberLogin tries to resolve and consume a remember-me cookie, logging
+// the visitor in on success. It reports whether it did so.
+func (a App) rememberLogin(w http.ResponseWriter, r *http.Request) bool {
+	if a.RememberTokens == nil {
+		return false
+	}
+	userSID, err := ResolveRememberToken(w, r, a.RememberTokens, a.RememberTokenTTL())
+	if err != nil {
+		return false
+	}
+	return a.Session.Login(w, r, userSID) == nil
+}
+
+// RedirectIfAuthenticated sends already logged in users to destination,
+// e.g. to keep them off the login page.
+func (a App) RedirectIfAuthenticated(destination string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.Session.IsAuthenticated(r) {
+				http.Redirect(w, r, destination, http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/alcalbg/gotdd"
 	"github.com/alcalbg/gotdd/test/assert"
@@ -13,17 +14,83 @@ import (
 )
 
 func TestCORS(t *testing.T) {
-	request := httptest.NewRequest(http.MethodOptions, "/sample", nil)
-	response := httptest.NewRecorder()
 
-	app := gotdd.App{}
-	CORS := app.Cors()
-	handler := CORS(http.NotFoundHandler())
+	t.Run("echoes an allowed origin and short-circuits a preflight with 204", func(t *testing.T) {
+		cfg := gotdd.CORSConfig{AllowedOrigins: []string{"https://example.com"}, MaxAge: time.Hour}
+		cfg.AllowRoute("/sample", []string{http.MethodGet, http.MethodPost}, []string{"Content-Type"})
 
-	handler.ServeHTTP(response, request)
+		request := httptest.NewRequest(http.MethodOptions, "/sample", nil)
+		request.Header.Set("Origin", "https://example.com")
+		request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		response := httptest.NewRecorder()
+
+		app := gotdd.App{CORS: cfg}
+		handler := app.Cors()(http.NotFoundHandler())
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNoContent, response.Code)
+		assert.Equal(t, "https://example.com", response.HeaderMap.Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, response.HeaderMap.Get("Access-Control-Allow-Methods"), "POST")
+		assert.Equal(t, "3600", response.HeaderMap.Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("wildcard subdomains are allowed", func(t *testing.T) {
+		cfg := gotdd.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}
+
+		request := httptest.NewRequest(http.MethodGet, "/sample", nil)
+		request.Header.Set("Origin", "https://api.example.com")
+		response := httptest.NewRecorder()
+
+		app := gotdd.App{CORS: cfg}
+		handler := app.Cors()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, "https://api.example.com", response.HeaderMap.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("a disallowed origin gets no Access-Control-Allow-Origin header", func(t *testing.T) {
+		cfg := gotdd.CORSConfig{AllowedOrigins: []string{"https://example.com"}}
+
+		request := httptest.NewRequest(http.MethodGet, "/sample", nil)
+		request.Header.Set("Origin", "https://evil.com")
+		response := httptest.NewRecorder()
 
-	assert.Equal(t, gotdd.CORSAllowedOrigin, response.HeaderMap.Get("Access-Control-Allow-Origin"))
-	assert.Equal(t, gotdd.CORSAllowedMethods, response.HeaderMap.Get("Access-Control-Allow-Methods"))
+		app := gotdd.App{CORS: cfg}
+		handler := app.Cors()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, "", response.HeaderMap.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("credentialed requests get Access-Control-Allow-Credentials", func(t *testing.T) {
+		cfg := gotdd.CORSConfig{AllowedOrigins: []string{"https://example.com"}, AllowCredentials: true}
+
+		request := httptest.NewRequest(http.MethodGet, "/sample", nil)
+		request.Header.Set("Origin", "https://example.com")
+		response := httptest.NewRecorder()
+
+		app := gotdd.App{CORS: cfg}
+		handler := app.Cors()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, "true", response.HeaderMap.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("a preflight for a method not registered for the route is rejected", func(t *testing.T) {
+		cfg := gotdd.CORSConfig{AllowedOrigins: []string{"https://example.com"}}
+		cfg.AllowRoute("/sample", []string{http.MethodGet}, nil)
+
+		request := httptest.NewRequest(http.MethodOptions, "/sample", nil)
+		request.Header.Set("Origin", "https://example.com")
+		request.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		response := httptest.NewRecorder()
+
+		app := gotdd.App{CORS: cfg}
+		handler := app.Cors()(http.NotFoundHandler())
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	})
 }
 
 func TestLogging(t *testing.T) {
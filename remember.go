@@ -0,0 +1,132 @@
+package gotdd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RememberCookieName is the cookie carrying the "selector:validator" pair.
+const RememberCookieName = "remember_me"
+
+// DefaultRememberTokenTTL is used when App.RememberTTL is unset.
+const DefaultRememberTokenTTL = 30 * 24 * time.Hour
+
+// ErrRememberTokenNotFound covers every way a remember-me cookie can fail
+// to resolve to a user: missing, malformed, unknown selector, bad
+// validator, or expired.
+var ErrRememberTokenNotFound = errors.New("remember token not found")
+
+// RememberToken is the Paragonie-style split token: Selector is the public,
+// indexed half; ValidatorHash is the sha256 of the secret half, so the
+// plaintext validator is never at rest.
+type RememberToken struct {
+	Selector      string
+	ValidatorHash string
+	UserSID       string
+	ExpiresAt     time.Time
+}
+
+// RememberTokenRepository persists RememberTokens. A Redis-backed
+// implementation works as well as an in-memory or SQL one; nothing here
+// assumes a particular store.
+type RememberTokenRepository interface {
+	Create(token RememberToken) error
+	FindBySelector(selector string) (RememberToken, error)
+	Delete(selector string) error
+	DeleteAllForUser(userSID string) error
+}
+
+// IssueRememberToken generates a fresh selector/validator pair for userSID,
+// persists it via repo, and sets the remember-me cookie on w.
+func IssueRememberToken(w http.ResponseWriter, repo RememberTokenRepository, userSID string, ttl time.Duration) error {
+	selector, err := randomToken(12)
+	if err != nil {
+		return err
+	}
+	validator, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	token := RememberToken{
+		Selector:      selector,
+		ValidatorHash: hashValidator(validator),
+		UserSID:       userSID,
+		ExpiresAt:     time.Now().Add(ttl),
+	}
+	if err := repo.Create(token); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RememberCookieName,
+		Value:    selector + ":" + validator,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  token.ExpiresAt,
+	})
+	return nil
+}
+
+// ResolveRememberToken looks up r's remember-me cookie, constant-time
+// compares the validator against the stored hash, and on success rotates
+// the token (the old one is deleted and a new one issued) before returning
+// the user it belongs to.
+//
+// A validator that doesn't match a known selector's hash means the cookie
+// was replayed after its token was already rotated, or forged outright —
+// either way the chain is considered compromised, so every token belonging
+// to that user is deleted.
+func ResolveRememberToken(w http.ResponseWriter, r *http.Request, repo RememberTokenRepository, ttl time.Duration) (string, error) {
+	cookie, err := r.Cookie(RememberCookieName)
+	if err != nil {
+		return "", ErrRememberTokenNotFound
+	}
+
+	selector, validator, ok := strings.Cut(cookie.Value, ":")
+	if !ok {
+		return "", ErrRememberTokenNotFound
+	}
+
+	token, err := repo.FindBySelector(selector)
+	if err != nil {
+		return "", ErrRememberTokenNotFound
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashValidator(validator)), []byte(token.ValidatorHash)) != 1 {
+		repo.DeleteAllForUser(token.UserSID)
+		return "", ErrRememberTokenNotFound
+	}
+
+	repo.Delete(selector)
+
+	if time.Now().After(token.ExpiresAt) {
+		return "", ErrRememberTokenNotFound
+	}
+
+	if err := IssueRememberToken(w, repo, token.UserSID, ttl); err != nil {
+		return "", err
+	}
+
+	return token.UserSID, nil
+}
+
+func hashValidator(validator string) string {
+	sum := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,86 @@
+package gotdd_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/alcalbg/gotdd/test/assert"
+	"github.com/alcalbg/gotdd/test/doubles"
+)
+
+func TestRememberToken(t *testing.T) {
+
+	t.Run("issued token resolves back to the same user", func(t *testing.T) {
+		repo := doubles.NewRememberTokenRepositoryStub()
+		response := httptest.NewRecorder()
+
+		assert.NoError(t, gotdd.IssueRememberToken(response, repo, "123", time.Hour))
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(response.Result().Cookies()[0])
+
+		gotSID, err := gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, "123", gotSID)
+	})
+
+	t.Run("token is rotated on use: the old cookie no longer resolves", func(t *testing.T) {
+		repo := doubles.NewRememberTokenRepositoryStub()
+		first := httptest.NewRecorder()
+		assert.NoError(t, gotdd.IssueRememberToken(first, repo, "123", time.Hour))
+		oldCookie := first.Result().Cookies()[0]
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(oldCookie)
+		_, err := gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		assert.NoError(t, err)
+
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(oldCookie)
+		_, err = gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		if err == nil {
+			t.Fatal("expected the rotated-out token to be rejected")
+		}
+	})
+
+	t.Run("a validator that doesn't match a known selector invalidates every token for that user", func(t *testing.T) {
+		repo := doubles.NewRememberTokenRepositoryStub()
+		response := httptest.NewRecorder()
+		assert.NoError(t, gotdd.IssueRememberToken(response, repo, "123", time.Hour))
+		cookie := response.Result().Cookies()[0]
+
+		forged := *cookie
+		forged.Value = cookie.Value[:len(cookie.Value)-1] + "x"
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(&forged)
+		_, err := gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		if err == nil {
+			t.Fatal("expected a forged validator to be rejected")
+		}
+
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(cookie)
+		_, err = gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		if err == nil {
+			t.Fatal("expected the legitimate token to be invalidated too, after a mismatched validator was seen")
+		}
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		repo := doubles.NewRememberTokenRepositoryStub()
+		response := httptest.NewRecorder()
+		assert.NoError(t, gotdd.IssueRememberToken(response, repo, "123", -time.Second))
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(response.Result().Cookies()[0])
+
+		_, err := gotdd.ResolveRememberToken(httptest.NewRecorder(), request, repo, time.Hour)
+		if err == nil {
+			t.Fatal("expected an expired token to be rejected")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package gotdd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// GuestSID is the session value used for a visitor who isn't logged in.
+const GuestSID = ""
+
+const sessionName = "gotdd"
+const sessionKeyUserSID = "user_sid"
+
+// Session wraps a gorilla session store and exposes the handful of
+// operations the app needs: who is logged in, and logging in/out.
+type Session struct {
+	store sessions.Store
+}
+
+// NewSession wraps the given store.
+func NewSession(store sessions.Store) *Session {
+	return &Session{store: store}
+}
+
+type sessionContextKey struct{}
+
+// getSession returns the same *sessions.Session for every call within one
+// request, regardless of how many times it's called or what the underlying
+// store does on its own Get() - that's what lets a single deferred save (see
+// save() below) pick up every mutation a request made along the way. The
+// lookup is cached on r itself (the same in-place WithContext trick gorilla's
+// own registry uses) since getSession has no way to hand callers back an
+// updated *http.Request.
+func (s *Session) getSession(r *http.Request) *sessions.Session {
+	if session, ok := r.Context().Value(sessionContextKey{}).(*sessions.Session); ok {
+		return session
+	}
+	session, _ := s.store.Get(r, sessionName)
+	*r = *r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session))
+	return session
+}
+
+type deferredSaveContextKey struct{}
+
+// save persists the session immediately, unless r is already inside
+// App.Flash(), which saves once right before the response is written -
+// see Flash(). Without this, a request that both clears a flash (Flash())
+// and then mutates the session again (e.g. AddFlash, Login) would emit
+// two Set-Cookie headers for the same cookie in one response.
+func (s *Session) save(w http.ResponseWriter, r *http.Request) error {
+	if r.Context().Value(deferredSaveContextKey{}) != nil {
+		return nil
+	}
+	return s.getSession(r).Save(r, w)
+}
+
+// UserSID returns the SID of the currently logged in user, or GuestSID.
+func (s *Session) UserSID(r *http.Request) string {
+	sid, _ := s.getSession(r).Values[sessionKeyUserSID].(string)
+	return sid
+}
+
+// IsAuthenticated reports whether the request belongs to a logged in user.
+func (s *Session) IsAuthenticated(r *http.Request) bool {
+	return s.UserSID(r) != GuestSID
+}
+
+// Login stores userSID in the session, logging the user in.
+func (s *Session) Login(w http.ResponseWriter, r *http.Request, userSID string) error {
+	session := s.getSession(r)
+	session.Values[sessionKeyUserSID] = userSID
+	return s.save(w, r)
+}
+
+// Logout clears the session.
+func (s *Session) Logout(w http.ResponseWriter, r *http.Request) error {
+	session := s.getSession(r)
+	delete(session.Values, sessionKeyUserSID)
+	session.Options.MaxAge = -1
+	return s.save(w, r)
+}
@@ -0,0 +1,40 @@
+// Package assert contains tiny test helpers used across the test suite.
+package assert
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Equal fails the test if got != want. The expected value comes first.
+func Equal(t *testing.T, want, got interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// Contains fails the test if s does not contain needle.
+func Contains(t *testing.T, s, needle string) {
+	t.Helper()
+	if !strings.Contains(s, needle) {
+		t.Errorf("%q does not contain %q", s, needle)
+	}
+}
+
+// Redirects fails the test unless response is a redirect to location with
+// the given status code.
+func Redirects(t *testing.T, response *httptest.ResponseRecorder, location string, status int) {
+	t.Helper()
+	Equal(t, status, response.Code)
+	Equal(t, location, response.Result().Header.Get("Location"))
+}
+
+// NoError fails the test if err is non-nil.
+func NoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
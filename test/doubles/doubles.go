@@ -0,0 +1,206 @@
+// Package doubles holds test doubles (stubs/spies) shared by the test suite.
+package doubles
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alcalbg/gotdd"
+	"github.com/gorilla/sessions"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserStub returns the single user known to NewUserRepositoryStub. It uses
+// bcrypt.MinCost so tests that care about wall-clock time (e.g. login
+// throttling) aren't at the mercy of a slow CI machine.
+func UserStub() gotdd.User {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pass123"), bcrypt.MinCost)
+	return gotdd.User{
+		SID:      "123",
+		Email:    "user@example.com",
+		Password: string(hash),
+	}
+}
+
+type userRepositoryStub struct {
+	user gotdd.User
+}
+
+// NewUserRepositoryStub returns a UserRepository containing only UserStub().
+func NewUserRepositoryStub() gotdd.UserRepository {
+	return &userRepositoryStub{user: UserStub()}
+}
+
+func (s *userRepositoryStub) GetUserByEmail(email string) (gotdd.User, error) {
+	if email != s.user.Email {
+		return gotdd.User{}, gotdd.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+// gorillaSessionStoreSpy starts out logged in as userSID and keeps all
+// session state (the login state as well as anything else, such as a CSRF
+// token or a flash message) in memory, no cookies involved, so it can be
+// observed across several requests in a test without a real cookie round
+// trip.
+type gorillaSessionStoreSpy struct {
+	userSID string
+	values  map[interface{}]interface{}
+}
+
+// NewGorillaSessionStoreSpy returns a sessions.Store that starts logged in
+// as userSID (pass gotdd.GuestSID for a guest).
+func NewGorillaSessionStoreSpy(userSID string) sessions.Store {
+	return &gorillaSessionStoreSpy{userSID: userSID}
+}
+
+func (s *gorillaSessionStoreSpy) Get(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	for k, v := range s.values {
+		session.Values[k] = v
+	}
+	session.Values["user_sid"] = s.userSID
+	return session, nil
+}
+
+func (s *gorillaSessionStoreSpy) New(r *http.Request, name string) (*sessions.Session, error) {
+	return s.Get(r, name)
+}
+
+func (s *gorillaSessionStoreSpy) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options != nil && session.Options.MaxAge < 0 {
+		s.userSID = gotdd.GuestSID
+		s.values = nil
+		return nil
+	}
+
+	s.values = map[interface{}]interface{}{}
+	for k, v := range session.Values {
+		if k != "user_sid" {
+			s.values[k] = v
+		}
+	}
+	if sid, ok := session.Values["user_sid"].(string); ok {
+		s.userSID = sid
+	}
+	return nil
+}
+
+type remoteUserRepositoryStub struct {
+	byKey map[string]*gotdd.RemoteUser
+	next  int
+}
+
+// NewRemoteUserRepositoryStub returns an in-memory RemoteUserRepository
+// suitable for exercising the OAuth login flow in tests.
+func NewRemoteUserRepositoryStub() gotdd.RemoteUserRepository {
+	return &remoteUserRepositoryStub{byKey: map[string]*gotdd.RemoteUser{}}
+}
+
+func (s *remoteUserRepositoryStub) FindOrCreate(sourceID, externalID, email string) (gotdd.RemoteUser, error) {
+	key := sourceID + ":" + externalID
+	if u, ok := s.byKey[key]; ok {
+		return *u, nil
+	}
+	s.next++
+	u := &gotdd.RemoteUser{
+		SID:        key,
+		SourceID:   sourceID,
+		ExternalID: externalID,
+		Email:      email,
+	}
+	s.byKey[key] = u
+	return *u, nil
+}
+
+func (s *remoteUserRepositoryStub) Promote(remoteSID string) (string, error) {
+	u, ok := s.byKey[remoteSID]
+	if !ok {
+		return "", gotdd.ErrUserNotFound
+	}
+	if u.UserSID == "" {
+		u.UserSID = "remote-" + remoteSID
+	}
+	return u.UserSID, nil
+}
+
+type rememberTokenRepositoryStub struct {
+	bySelector map[string]gotdd.RememberToken
+}
+
+// NewRememberTokenRepositoryStub returns an in-memory RememberTokenRepository.
+func NewRememberTokenRepositoryStub() gotdd.RememberTokenRepository {
+	return &rememberTokenRepositoryStub{bySelector: map[string]gotdd.RememberToken{}}
+}
+
+func (s *rememberTokenRepositoryStub) Create(token gotdd.RememberToken) error {
+	s.bySelector[token.Selector] = token
+	return nil
+}
+
+func (s *rememberTokenRepositoryStub) FindBySelector(selector string) (gotdd.RememberToken, error) {
+	token, ok := s.bySelector[selector]
+	if !ok {
+		return gotdd.RememberToken{}, gotdd.ErrRememberTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *rememberTokenRepositoryStub) Delete(selector string) error {
+	delete(s.bySelector, selector)
+	return nil
+}
+
+func (s *rememberTokenRepositoryStub) DeleteAllForUser(userSID string) error {
+	for selector, token := range s.bySelector {
+		if token.UserSID == userSID {
+			delete(s.bySelector, selector)
+		}
+	}
+	return nil
+}
+
+type fileSystemStub struct {
+	files map[string][]byte
+}
+
+// NewFileSystemStub returns an http.FileSystem serving the given in-memory
+// files, keyed by path without a leading slash.
+func NewFileSystemStub(files map[string][]byte) http.FileSystem {
+	return &fileSystemStub{files: files}
+}
+
+func (fsys *fileSystemStub) Open(name string) (http.File, error) {
+	name = strings.TrimPrefix(name, "/")
+	content, ok := fsys.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &fileStub{Reader: bytes.NewReader(content), name: name, size: int64(len(content))}, nil
+}
+
+// fileStub is the minimal http.File needed to serve a single in-memory file.
+type fileStub struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (f *fileStub) Close() error                     { return nil }
+func (f *fileStub) Readdir(int) ([]os.FileInfo, error) { return nil, nil }
+func (f *fileStub) Stat() (os.FileInfo, error)        { return fileInfoStub{f.name, f.size}, nil }
+
+type fileInfoStub struct {
+	name string
+	size int64
+}
+
+func (i fileInfoStub) Name() string       { return i.name }
+func (i fileInfoStub) Size() int64        { return i.size }
+func (i fileInfoStub) Mode() os.FileMode  { return 0 }
+func (i fileInfoStub) ModTime() time.Time { return time.Time{} }
+func (i fileInfoStub) IsDir() bool        { return false }
+func (i fileInfoStub) Sys() interface{}   { return nil }
@@ -0,0 +1,104 @@
+package gotdd
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultMinLoginDuration is used when App.MinLoginDuration is unset.
+const DefaultMinLoginDuration = 500 * time.Millisecond
+
+// LoginThrottler decides whether a login attempt for a given (email,
+// client IP) pair may proceed, to slow down credential stuffing.
+type LoginThrottler interface {
+	// Allow reports whether an attempt for (email, clientIP) may proceed.
+	// When it can't, retryAfter is how long the caller should wait.
+	Allow(email, clientIP string) (ok bool, retryAfter time.Duration)
+	RecordFailure(email, clientIP string)
+	// RecordSuccess clears any failures tracked for (email, clientIP).
+	RecordSuccess(email, clientIP string)
+}
+
+// InMemoryLoginThrottler is the default LoginThrottler: a sliding window of
+// failure timestamps per (email, clientIP), kept in memory. A
+// Redis-backed implementation satisfying the same interface is a drop-in
+// replacement for sharing state across instances.
+type InMemoryLoginThrottler struct {
+	MaxAttempts int
+	Window      time.Duration
+
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+// NewInMemoryLoginThrottler locks a (email, clientIP) pair out once it
+// has accrued maxAttempts failures within window.
+func NewInMemoryLoginThrottler(maxAttempts int, window time.Duration) *InMemoryLoginThrottler {
+	return &InMemoryLoginThrottler{
+		MaxAttempts: maxAttempts,
+		Window:      window,
+		failures:    map[string][]time.Time{},
+	}
+}
+
+func (t *InMemoryLoginThrottler) Allow(email, clientIP string) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attempts := t.prune(throttleKey(email, clientIP))
+	if len(attempts) < t.MaxAttempts {
+		return true, 0
+	}
+
+	retryAfter := t.Window - time.Since(attempts[0])
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return false, retryAfter
+}
+
+func (t *InMemoryLoginThrottler) RecordFailure(email, clientIP string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := throttleKey(email, clientIP)
+	t.failures[key] = append(t.prune(key), time.Now())
+}
+
+func (t *InMemoryLoginThrottler) RecordSuccess(email, clientIP string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.failures, throttleKey(email, clientIP))
+}
+
+// prune drops failures that have aged out of the window. Callers must
+// hold t.mu.
+func (t *InMemoryLoginThrottler) prune(key string) []time.Time {
+	cutoff := time.Now().Add(-t.Window)
+	kept := t.failures[key][:0]
+	for _, at := range t.failures[key] {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	t.failures[key] = kept
+	return kept
+}
+
+func throttleKey(email, clientIP string) string {
+	return email + "|" + clientIP
+}
+
+// PadLoginDuration sleeps, if necessary, so that at least minDuration
+// (plus a little jitter) has elapsed since start. Call it before
+// responding to a failed login so that a nonexistent email and a wrong
+// password - which take different code paths - are indistinguishable by
+// timing.
+func PadLoginDuration(start time.Time, minDuration time.Duration) {
+	target := minDuration + time.Duration(rand.Int63n(int64(50*time.Millisecond)))
+	if elapsed := time.Since(start); elapsed < target {
+		time.Sleep(target - elapsed)
+	}
+}
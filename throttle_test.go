@@ -0,0 +1,64 @@
+package gotdd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alcalbg/gotdd"
+)
+
+func TestInMemoryLoginThrottler(t *testing.T) {
+
+	t.Run("allows attempts under the threshold", func(t *testing.T) {
+		throttler := gotdd.NewInMemoryLoginThrottler(3, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			if ok, _ := throttler.Allow("a@b.com", "1.2.3.4"); !ok {
+				t.Fatalf("attempt %d should be allowed", i)
+			}
+			throttler.RecordFailure("a@b.com", "1.2.3.4")
+		}
+	})
+
+	t.Run("locks out after the threshold and reports a retry-after", func(t *testing.T) {
+		throttler := gotdd.NewInMemoryLoginThrottler(2, time.Minute)
+		throttler.RecordFailure("a@b.com", "1.2.3.4")
+		throttler.RecordFailure("a@b.com", "1.2.3.4")
+
+		ok, retryAfter := throttler.Allow("a@b.com", "1.2.3.4")
+		if ok {
+			t.Fatal("expected the third attempt to be throttled")
+		}
+		if retryAfter <= 0 || retryAfter > time.Minute {
+			t.Fatalf("unexpected retry-after: %v", retryAfter)
+		}
+	})
+
+	t.Run("different client IPs are tracked independently", func(t *testing.T) {
+		throttler := gotdd.NewInMemoryLoginThrottler(1, time.Minute)
+		throttler.RecordFailure("a@b.com", "1.1.1.1")
+
+		if ok, _ := throttler.Allow("a@b.com", "2.2.2.2"); !ok {
+			t.Fatal("a different client IP should not be throttled")
+		}
+	})
+
+	t.Run("a success clears the tracked failures", func(t *testing.T) {
+		throttler := gotdd.NewInMemoryLoginThrottler(1, time.Minute)
+		throttler.RecordFailure("a@b.com", "1.2.3.4")
+		throttler.RecordSuccess("a@b.com", "1.2.3.4")
+
+		if ok, _ := throttler.Allow("a@b.com", "1.2.3.4"); !ok {
+			t.Fatal("expected the throttle to reset after a success")
+		}
+	})
+}
+
+func TestPadLoginDuration(t *testing.T) {
+	start := time.Now()
+	gotdd.PadLoginDuration(start, 30*time.Millisecond)
+
+	if time.Since(start) < 30*time.Millisecond {
+		t.Fatal("expected PadLoginDuration to wait at least the minimum duration")
+	}
+}
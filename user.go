@@ -0,0 +1,19 @@
+package gotdd
+
+import "errors"
+
+// ErrUserNotFound is returned by UserRepository.GetUserByEmail when no user
+// matches the given email.
+var ErrUserNotFound = errors.New("user not found")
+
+// User represents an authenticated local account.
+type User struct {
+	SID      string
+	Email    string
+	Password string // bcrypt hash
+}
+
+// UserRepository gives access to the persisted users.
+type UserRepository interface {
+	GetUserByEmail(email string) (User, error)
+}